@@ -0,0 +1,86 @@
+package scrapeconfig
+
+import (
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/server"
+)
+
+// GcplogTargetConfig describes a scrape config for a GCP Pub/Sub backed
+// gcplog target, either a push subscription (an HTTP endpoint GCP delivers
+// messages to) or a pull subscription (polled via the Pub/Sub client).
+type GcplogTargetConfig struct {
+	// ProjectID is the GCP project the subscription lives in.
+	ProjectID string `mapstructure:"project_id" yaml:"project_id"`
+
+	// Subscription is the name of the Pub/Sub subscription to pull from.
+	// Only used by pull subscriptions.
+	Subscription string `mapstructure:"subscription" yaml:"subscription"`
+
+	// Labels optionally adds static labels to all incoming log entries.
+	Labels model.LabelSet `mapstructure:"labels" yaml:"labels"`
+
+	// UseIncomingTimestamp sets the entry timestamp to the LogEntry's own
+	// timestamp, instead of the time the entry was scraped.
+	UseIncomingTimestamp bool `mapstructure:"use_incoming_timestamp" yaml:"use_incoming_timestamp"`
+
+	// TenantIDAttribute, if set, stamps entries with the tenant named by this
+	// Pub/Sub message attribute, taking precedence over TenantIDLabel and the
+	// request's X-Scope-OrgID header, allowing a single subscription to fan
+	// out log entries across many tenants.
+	TenantIDAttribute string `mapstructure:"tenant_id_attribute" yaml:"tenant_id_attribute"`
+
+	// TenantIDLabel is the LogEntry resource label to fall back to for the
+	// tenant ID when TenantIDAttribute is unset or absent on a message.
+	TenantIDLabel string `mapstructure:"tenant_id_label" yaml:"tenant_id_label"`
+
+	// NumWorkers is the number of goroutines a pull subscription uses to
+	// process received messages concurrently. Defaults to 1 when unset.
+	NumWorkers int `mapstructure:"num_workers" yaml:"num_workers"`
+
+	// MaxOutstandingMessages bounds how many pull-subscription messages may
+	// be outstanding (received but not yet acked/nacked) at once. Zero uses
+	// the Pub/Sub client's own default.
+	MaxOutstandingMessages int `mapstructure:"max_outstanding_messages" yaml:"max_outstanding_messages"`
+
+	// MaxOutstandingBytes bounds the total size of outstanding pull
+	// subscription messages. Zero uses the Pub/Sub client's own default.
+	MaxOutstandingBytes int `mapstructure:"max_outstanding_bytes" yaml:"max_outstanding_bytes"`
+
+	// Synchronous disables the Pub/Sub client's internal batching of receive
+	// calls. Zero uses the Pub/Sub client's own default (false).
+	Synchronous bool `mapstructure:"synchronous" yaml:"synchronous"`
+
+	// Server configures the HTTP server a push subscription listens on.
+	// Only used by push subscriptions.
+	Server *server.Config `mapstructure:"server" yaml:"server"`
+
+	// PushAuthentication, if set, requires push requests to carry a valid
+	// GCP-signed OIDC identity token. Only used by push subscriptions.
+	PushAuthentication *PushAuthenticationConfig `mapstructure:"push_authentication" yaml:"push_authentication"`
+
+	// LogFormat selects the structured logging output for this target's own
+	// operational logs: "logfmt" (the default when unset) routes through the
+	// shared go-kit logger, while "json" writes machine-readable JSON
+	// straight to stdout.
+	LogFormat string `mapstructure:"log_format" yaml:"log_format"`
+}
+
+// PushAuthenticationConfig configures OIDC identity token verification for a
+// gcplog push subscription's HTTP endpoint.
+// See: https://cloud.google.com/pubsub/docs/authenticate-push-subscriptions
+type PushAuthenticationConfig struct {
+	// Audience is the expected "aud" claim on the identity token, normally
+	// the push endpoint's own URL.
+	Audience string `mapstructure:"audience" yaml:"audience"`
+
+	// AllowedServiceAccounts restricts which service account emails may push,
+	// checked against the token's "email" claim. Empty allows any account
+	// that otherwise passes verification.
+	AllowedServiceAccounts []string `mapstructure:"allowed_service_accounts" yaml:"allowed_service_accounts"`
+
+	// JWKSRefreshInterval bounds how long Google's public keys are cached
+	// for. Defaults to 1 hour when unset.
+	JWKSRefreshInterval time.Duration `mapstructure:"jwks_refresh_interval" yaml:"jwks_refresh_interval"`
+}
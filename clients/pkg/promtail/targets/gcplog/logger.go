@@ -0,0 +1,20 @@
+package gcplog
+
+import (
+	"log/slog"
+
+	"github.com/go-kit/log"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+// newTargetLogger builds the *slog.Logger a push or pull target logs
+// through: logFormat "json" opts into machine-readable JSON on stdout,
+// independent of the shared go-kit sink, while anything else (including
+// unset) adapts logger, the promtail-wide go-kit logger, onto log/slog.
+func newTargetLogger(logger log.Logger, logFormat string) *slog.Logger {
+	if logFormat == "json" {
+		return util_log.NewJSONSlog(slog.LevelInfo)
+	}
+	return util_log.NewSlog(logger)
+}
@@ -0,0 +1,29 @@
+package gcplog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTargetLogger_JSONFormatBypassesGoKitSink(t *testing.T) {
+	var buf bytes.Buffer
+	goKitLogger := log.NewLogfmtLogger(&buf)
+
+	logger := newTargetLogger(goKitLogger, "json")
+	logger.Info("hello")
+
+	require.Empty(t, buf.String(), "a json-format logger must not write through the go-kit sink")
+}
+
+func TestNewTargetLogger_DefaultFormatUsesGoKitSink(t *testing.T) {
+	var buf bytes.Buffer
+	goKitLogger := log.NewLogfmtLogger(&buf)
+
+	logger := newTargetLogger(goKitLogger, "")
+	logger.Info("hello")
+
+	require.Contains(t, buf.String(), "hello")
+}
@@ -0,0 +1,97 @@
+package gcplog
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds the set of metrics for both the push and pull flavours of
+// the gcplog target, registered once per promtail instance and shared by
+// every target the instance runs.
+type Metrics struct {
+	reg prometheus.Registerer
+
+	gcplogEntries                 *prometheus.CounterVec
+	gcplogErrors                  *prometheus.CounterVec
+	gcplogTargetLastSuccessScrape *prometheus.GaugeVec
+	gcplogOutstandingMessages     *prometheus.GaugeVec
+	gcplogProcessingLatency       *prometheus.HistogramVec
+
+	gcpPushEntries    *prometheus.CounterVec
+	gcpPushErrors     *prometheus.CounterVec
+	gcpPushAuthErrors *prometheus.CounterVec
+}
+
+// NewMetrics creates and registers the gcplog target metrics.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+	m.reg = reg
+
+	m.gcplogEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Subsystem: "gcplog",
+		Name:      "entries_total",
+		Help:      "Number of entries collected from a pull subscription, per project and tenant.",
+	}, []string{"project_id", "tenant"})
+
+	m.gcplogErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Subsystem: "gcplog",
+		Name:      "errors_total",
+		Help:      "Number of errors encountered while consuming a pull subscription, per project.",
+	}, []string{"project_id"})
+
+	m.gcplogTargetLastSuccessScrape = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "promtail",
+		Subsystem: "gcplog",
+		Name:      "target_last_success_scrape",
+		Help:      "Timestamp of the last successful consumption of a pull subscription, per project and subscription.",
+	}, []string{"project_id", "subscription"})
+
+	m.gcplogOutstandingMessages = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "promtail",
+		Subsystem: "gcplog",
+		Name:      "outstanding_messages",
+		Help:      "Number of pull subscription messages received but not yet acked or nacked, per project.",
+	}, []string{"project_id"})
+
+	m.gcplogProcessingLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "promtail",
+		Subsystem: "gcplog",
+		Name:      "processing_latency_seconds",
+		Help:      "Time taken by a worker to format and hand off a pull subscription message, per project.",
+	}, []string{"project_id"})
+
+	m.gcpPushEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Subsystem: "gcplog",
+		Name:      "push_entries_total",
+		Help:      "Number of entries received by a push subscription's HTTP endpoint, per tenant.",
+	}, []string{"tenant"})
+
+	m.gcpPushErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Subsystem: "gcplog",
+		Name:      "push_errors_total",
+		Help:      "Number of requests a push subscription's HTTP endpoint failed to read, parse or translate.",
+	}, []string{})
+
+	m.gcpPushAuthErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Subsystem: "gcplog",
+		Name:      "push_auth_errors_total",
+		Help:      "Number of push requests rejected for failing identity token verification.",
+	}, []string{})
+
+	if m.reg != nil {
+		m.reg.MustRegister(
+			m.gcplogEntries,
+			m.gcplogErrors,
+			m.gcplogTargetLastSuccessScrape,
+			m.gcplogOutstandingMessages,
+			m.gcplogProcessingLatency,
+			m.gcpPushEntries,
+			m.gcpPushErrors,
+			m.gcpPushAuthErrors,
+		)
+	}
+
+	return &m
+}
@@ -2,11 +2,13 @@ package gcplog
 
 import (
 	"context"
+	"encoding/json"
+	"log/slog"
 	"sync"
+	"time"
 
 	"cloud.google.com/go/pubsub"
 	"github.com/go-kit/log"
-	"github.com/go-kit/log/level"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
 	"google.golang.org/api/option"
@@ -14,19 +16,41 @@ import (
 	"github.com/grafana/loki/clients/pkg/promtail/api"
 	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/target"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
 )
 
+// pubsubRetryLogDedupWindow is the window within which retryLogger
+// suppresses repeats of the "failed to receive pubsub messages" line. run
+// calls sub.Receive once and logs that line when Receive returns an error.
+const pubsubRetryLogDedupWindow = 30 * time.Second
+
+// defaultNumWorkers is used when config.NumWorkers is unset, matching the
+// single-goroutine behaviour the receive loop used to have.
+const defaultNumWorkers = 1
+
 // pullTarget represents the target specific to GCP project, with a pull subscription type.
 // It collects logs from GCP and push it to Loki.
+// When config.TenantIDAttribute (or config.TenantIDLabel) is set, a single subscription
+// can fan out log entries across many tenants based on the message's attributes.
+// Messages are received and processed by a pool of config.NumWorkers workers so the
+// target can saturate a high-throughput subscription; config.MaxOutstandingMessages,
+// config.MaxOutstandingBytes and config.Synchronous tune the client's flow control.
 // nolint:revive
 type pullTarget struct {
 	metrics       *Metrics
-	logger        log.Logger
+	logger        *slog.Logger
+	retryLogger   *slog.Logger
 	handler       api.EntryHandler
 	config        *scrapeconfig.GcplogTargetConfig
 	relabelConfig []*relabel.Config
 	jobName       string
 
+	// formatFn defaults to the package-level format() but is overridable in
+	// tests so the worker pool's Ack/Nack/backpressure handling can be
+	// exercised without a real Pub/Sub subscription behind it.
+	formatFn func(m *pubsub.Message, lbls model.LabelSet, useIncomingTimestamp bool, relabelConfig []*relabel.Config, tenantID string) (api.Entry, error)
+
 	// lifecycle management
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -42,6 +66,7 @@ type pullTarget struct {
 // and push it Loki via given `api.EntryHandler.`
 // It starts the `run` loop to consume log entries that can be
 // stopped via `target.Stop()`
+// logger may still be a go-kit log.Logger; it is adapted onto log/slog internally.
 // nolint:revive,govet
 func newPullTarget(
 	metrics *Metrics,
@@ -59,13 +84,18 @@ func newPullTarget(
 		return nil, err
 	}
 
+	slogger := newTargetLogger(logger, config.LogFormat)
 	target := &pullTarget{
-		metrics:       metrics,
-		logger:        logger,
+		metrics: metrics,
+		logger:  slogger,
+		// Repeats of the "failed to receive" line within
+		// pubsubRetryLogDedupWindow are suppressed.
+		retryLogger:   slog.New(util_log.NewDedupingSlog(slogger.Handler(), pubsubRetryLogDedupWindow)),
 		handler:       handler,
 		relabelConfig: relabel,
 		config:        config,
 		jobName:       jobName,
+		formatFn:      format,
 		ctx:           ctx,
 		cancel:        cancel,
 		ps:            ps,
@@ -83,40 +113,131 @@ func (t *pullTarget) run() error {
 	t.wg.Add(1)
 	defer t.wg.Done()
 
-	send := t.handler.Chan()
-
 	sub := t.ps.SubscriptionInProject(t.config.Subscription, t.config.ProjectID)
+	sub.ReceiveSettings = t.receiveSettings()
+
+	numWorkers := t.config.NumWorkers
+	if numWorkers <= 0 {
+		numWorkers = defaultNumWorkers
+	}
+
+	var workers sync.WaitGroup
+	workers.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer workers.Done()
+			t.processMessages()
+		}()
+	}
+
 	go func() {
 		// NOTE(kavi): `cancel` the context as exiting from this goroutine should stop main `run` loop
 		// It makesense as no more messages will be received.
 		defer t.cancel()
+		defer close(t.msgs)
 
 		err := sub.Receive(t.ctx, func(ctx context.Context, m *pubsub.Message) {
+			t.metrics.gcplogOutstandingMessages.WithLabelValues(t.config.ProjectID).Inc()
 			t.msgs <- m
 		})
 		if err != nil {
-			level.Error(t.logger).Log("msg", "failed to receive pubsub messages", "error", err)
+			t.retryLogger.Error("failed to receive pubsub messages", "error", err)
 			t.metrics.gcplogErrors.WithLabelValues(t.config.ProjectID).Inc()
 			t.metrics.gcplogTargetLastSuccessScrape.WithLabelValues(t.config.ProjectID, t.config.Subscription).SetToCurrentTime()
 		}
 	}()
 
-	for {
+	<-t.ctx.Done()
+	workers.Wait()
+	return t.ctx.Err()
+}
+
+// processMessages is run by each worker in the pool, formatting and sending
+// messages off t.msgs concurrently. It Acks a message once it has been
+// handed to the handler, and Nacks it if formatting or sending failed so
+// Pub/Sub can redeliver it and exert backpressure upstream.
+func (t *pullTarget) processMessages() {
+	send := t.handler.Chan()
+
+	for m := range t.msgs {
+		start := time.Now()
+		tenantID := t.resolveTenantID(m)
+
+		entry, err := t.formatFn(m, t.config.Labels, t.config.UseIncomingTimestamp, t.relabelConfig, tenantID)
+		if err != nil {
+			t.logger.Error("error formating log entry", "cause", err)
+			m.Nack()
+			t.metrics.gcplogOutstandingMessages.WithLabelValues(t.config.ProjectID).Dec()
+			continue
+		}
+
 		select {
-		case <-t.ctx.Done():
-			return t.ctx.Err()
-		case m := <-t.msgs:
-			entry, err := format(m, t.config.Labels, t.config.UseIncomingTimestamp, t.relabelConfig)
-			if err != nil {
-				level.Error(t.logger).Log("event", "error formating log entry", "cause", err)
-				m.Ack()
-				break
-			}
-			send <- entry
+		case send <- entry:
 			m.Ack() // Ack only after log is sent.
-			t.metrics.gcplogEntries.WithLabelValues(t.config.ProjectID).Inc()
+			t.metrics.gcplogEntries.WithLabelValues(t.config.ProjectID, tenantID).Inc()
+		case <-t.ctx.Done():
+			m.Nack()
 		}
+
+		t.metrics.gcplogOutstandingMessages.WithLabelValues(t.config.ProjectID).Dec()
+		t.metrics.gcplogProcessingLatency.WithLabelValues(t.config.ProjectID).Observe(time.Since(start).Seconds())
+	}
+}
+
+// receiveSettings translates the target's flow-control knobs into the
+// pubsub.ReceiveSettings the client library uses to throttle how much work
+// it hands to our callback. Zero MaxOutstandingMessages and
+// MaxOutstandingBytes fall through to the client's own defaults;
+// NumGoroutines is the worker count, 1 when NumWorkers is unset.
+func (t *pullTarget) receiveSettings() pubsub.ReceiveSettings {
+	numWorkers := t.config.NumWorkers
+	if numWorkers <= 0 {
+		numWorkers = defaultNumWorkers
+	}
+
+	return pubsub.ReceiveSettings{
+		NumGoroutines:          numWorkers,
+		MaxOutstandingMessages: t.config.MaxOutstandingMessages,
+		MaxOutstandingBytes:    t.config.MaxOutstandingBytes,
+		Synchronous:            t.config.Synchronous,
+	}
+}
+
+// resolveTenantID picks the org ID to stamp on the entry, preferring the
+// Pub/Sub message attribute named by config.TenantIDAttribute over the
+// LogEntry resource label named by config.TenantIDLabel, and returning an
+// empty tenant ID when neither yields one, allowing a single pull
+// subscription to serve many tenants.
+func (t *pullTarget) resolveTenantID(m *pubsub.Message) string {
+	if t.config.TenantIDAttribute != "" {
+		if tenant, ok := m.Attributes[t.config.TenantIDAttribute]; ok && tenant != "" {
+			return tenant
+		}
+	}
+
+	if t.config.TenantIDLabel != "" {
+		if tenant, ok := resourceLabel(m.Data, t.config.TenantIDLabel); ok && tenant != "" {
+			return tenant
+		}
+	}
+
+	return ""
+}
+
+// resourceLabel decodes data as a GCP LogEntry and returns the named
+// resource label, e.g. resource.labels.project_id. GCP's LogEntry format is
+// documented at https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry.
+func resourceLabel(data []byte, name string) (string, bool) {
+	var entry struct {
+		Resource struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "", false
 	}
+	v, ok := entry.Resource.Labels[name]
+	return v, ok
 }
 
 func (t *pullTarget) Type() target.TargetType {
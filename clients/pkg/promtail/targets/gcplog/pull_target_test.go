@@ -0,0 +1,107 @@
+package gcplog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/grafana/loki/clients/pkg/promtail/api"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+type fakeEntryHandler struct {
+	entries chan api.Entry
+}
+
+func newFakeEntryHandler() *fakeEntryHandler {
+	return &fakeEntryHandler{entries: make(chan api.Entry, 10)}
+}
+
+func (h *fakeEntryHandler) Chan() chan<- api.Entry { return h.entries }
+func (h *fakeEntryHandler) Stop()                  { close(h.entries) }
+
+// TestPullTarget_ProcessMessages_AcksOnSuccessNacksOnFormatError exercises a
+// worker's real Ack/Nack path against a fake (but protocol-accurate) Pub/Sub
+// backend: a message that fails to format must be Nacked - and therefore
+// redelivered by Pub/Sub - while a message that formats successfully must be
+// Acked exactly once.
+func TestPullTarget_ProcessMessages_AcksOnSuccessNacksOnFormatError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := pstest.NewServer()
+	defer srv.Close()
+
+	conn, err := grpc.Dial(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client, err := pubsub.NewClient(ctx, "test-project", option.WithGRPCConn(conn))
+	require.NoError(t, err)
+	defer client.Close()
+
+	topic, err := client.CreateTopic(ctx, "test-topic")
+	require.NoError(t, err)
+	sub, err := client.CreateSubscription(ctx, "test-sub", pubsub.SubscriptionConfig{Topic: topic})
+	require.NoError(t, err)
+
+	var goodCount, badCount int32
+	handler := newFakeEntryHandler()
+	target := &pullTarget{
+		metrics: NewMetrics(nil),
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		handler: handler,
+		config:  &scrapeconfig.GcplogTargetConfig{ProjectID: "test-project"},
+		formatFn: func(m *pubsub.Message, _ model.LabelSet, _ bool, _ []*relabel.Config, _ string) (api.Entry, error) {
+			if string(m.Data) == "bad" {
+				atomic.AddInt32(&badCount, 1)
+				return api.Entry{}, errors.New("boom")
+			}
+			atomic.AddInt32(&goodCount, 1)
+			return api.Entry{Entry: logproto.Entry{Line: string(m.Data)}}, nil
+		},
+		ctx:  ctx,
+		msgs: make(chan *pubsub.Message),
+	}
+
+	go target.processMessages()
+
+	go func() {
+		_ = sub.Receive(ctx, func(_ context.Context, m *pubsub.Message) {
+			target.msgs <- m
+		})
+	}()
+
+	_, err = topic.Publish(ctx, &pubsub.Message{Data: []byte("good")}).Get(ctx)
+	require.NoError(t, err)
+	_, err = topic.Publish(ctx, &pubsub.Message{Data: []byte("bad")}).Get(ctx)
+	require.NoError(t, err)
+
+	select {
+	case e := <-handler.entries:
+		require.Equal(t, "good", e.Line)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the successfully formatted entry to reach the handler")
+	}
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&badCount) >= 2
+	}, 10*time.Second, 50*time.Millisecond, "a Nacked message must be redelivered by Pub/Sub")
+
+	time.Sleep(200 * time.Millisecond)
+	require.EqualValues(t, 1, atomic.LoadInt32(&goodCount), "an Acked message must not be redelivered")
+}
@@ -0,0 +1,213 @@
+package gcplog
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+)
+
+// googleCertsURL is Google's JWKS endpoint for verifying OIDC identity tokens
+// minted for Pub/Sub push subscriptions.
+// See: https://cloud.google.com/pubsub/docs/authenticate-push-subscriptions
+const googleCertsURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+const googleIssuer = "https://accounts.google.com"
+
+// pushAuthenticator validates the `Authorization: Bearer <jwt>` header GCP
+// attaches to authenticated push requests, caching Google's public keys and
+// refreshing them on the configured interval.
+type pushAuthenticator struct {
+	audience        string
+	allowedAccounts map[string]struct{}
+	refreshInterval time.Duration
+	httpClient      *http.Client
+	certsURL        string
+	logger          *slog.Logger
+
+	mtx     sync.RWMutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+func newPushAuthenticator(cfg *scrapeconfig.PushAuthenticationConfig, logger *slog.Logger) *pushAuthenticator {
+	allowed := make(map[string]struct{}, len(cfg.AllowedServiceAccounts))
+	for _, sa := range cfg.AllowedServiceAccounts {
+		allowed[sa] = struct{}{}
+	}
+
+	refresh := cfg.JWKSRefreshInterval
+	if refresh <= 0 {
+		refresh = 1 * time.Hour
+	}
+
+	return &pushAuthenticator{
+		audience:        cfg.Audience,
+		allowedAccounts: allowed,
+		refreshInterval: refresh,
+		httpClient:      http.DefaultClient,
+		certsURL:        googleCertsURL,
+		logger:          logger,
+	}
+}
+
+// authenticate validates the bearer token on r against Google's JWKS, the
+// configured audience and the allowed service account list.
+func (a *pushAuthenticator) authenticate(r *http.Request) error {
+	tok := bearerToken(r)
+	if tok == "" {
+		return fmt.Errorf("missing bearer token")
+	}
+
+	keys, err := a.jwksKeys(r.Context())
+	if err != nil {
+		return fmt.Errorf("failed to fetch google jwks: %w", err)
+	}
+
+	claims := jwt.MapClaims{}
+	parsed, err := jwt.ParseWithClaims(tok, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	})
+	if err != nil || !parsed.Valid {
+		return fmt.Errorf("invalid identity token: %w", err)
+	}
+
+	if !claims.VerifyIssuer(googleIssuer, true) {
+		return fmt.Errorf("unexpected issuer: %v", claims["iss"])
+	}
+	if !claims.VerifyAudience(a.audience, true) {
+		return fmt.Errorf("unexpected audience: %v", claims["aud"])
+	}
+
+	email, _ := claims["email"].(string)
+	if len(a.allowedAccounts) > 0 {
+		if _, ok := a.allowedAccounts[email]; !ok {
+			return fmt.Errorf("service account %q is not allowed", email)
+		}
+	}
+
+	return nil
+}
+
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(h, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(h, prefix)
+}
+
+// jwksKeys returns the cached Google public keys, refreshing them if the
+// cache is empty or past its refresh interval.
+func (a *pushAuthenticator) jwksKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	a.mtx.RLock()
+	fresh := a.keys != nil && time.Since(a.fetched) < a.refreshInterval
+	keys := a.keys
+	a.mtx.RUnlock()
+
+	if fresh {
+		return keys, nil
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	// Another goroutine may have refreshed the cache while we waited on the lock.
+	if a.keys != nil && time.Since(a.fetched) < a.refreshInterval {
+		return a.keys, nil
+	}
+
+	keys, err := fetchGoogleJWKS(ctx, a.httpClient, a.certsURL)
+	if err != nil {
+		if a.keys != nil {
+			// Serve stale keys rather than failing every request during a transient outage.
+			a.logger.Warn("failed to refresh google jwks, serving stale cache", "err", err)
+			return a.keys, nil
+		}
+		return nil, err
+	}
+
+	a.keys = keys
+	a.fetched = time.Now()
+	return a.keys, nil
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Kty string `json:"kty"`
+}
+
+type jwksResponse struct {
+	Keys []jwk `json:"keys"`
+}
+
+func fetchGoogleJWKS(ctx context.Context, client *http.Client, certsURL string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, certsURL)
+	}
+
+	var parsed jwksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(parsed.Keys))
+	for _, k := range parsed.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		pub, err := rsaPublicKeyFromJWK(k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse jwk %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = pub
+	}
+
+	return keys, nil
+}
+
+func rsaPublicKeyFromJWK(k jwk) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	n := new(big.Int).SetBytes(nBytes)
+	e := new(big.Int).SetBytes(eBytes)
+
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
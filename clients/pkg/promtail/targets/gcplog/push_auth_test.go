@@ -0,0 +1,162 @@
+package gcplog
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+const testServiceAccount = "promtail@test-project.iam.gserviceaccount.com"
+
+// testJWKS runs a fake JWKS endpoint backed by a single RSA key pair, so
+// tokens can be signed and verified end-to-end without talking to Google.
+type testJWKS struct {
+	srv        *httptest.Server
+	key        *rsa.PrivateKey
+	kid        string
+	statusCode int
+}
+
+func newTestJWKS(t *testing.T) *testJWKS {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	j := &testJWKS{key: key, kid: "test-key", statusCode: http.StatusOK}
+	j.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if j.statusCode != http.StatusOK {
+			w.WriteHeader(j.statusCode)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(jwksResponse{Keys: []jwk{{
+			Kid: j.kid,
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(j.srv.Close)
+	return j
+}
+
+type tokenOpt func(jwt.MapClaims)
+
+func (j *testJWKS) sign(t *testing.T, opts ...tokenOpt) string {
+	t.Helper()
+	claims := jwt.MapClaims{
+		"iss":   googleIssuer,
+		"aud":   "https://push.example.com/gcp/api/v1/push",
+		"email": testServiceAccount,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	for _, opt := range opts {
+		opt(claims)
+	}
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = j.kid
+	signed, err := tok.SignedString(j.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func newAuthenticator(jwks *testJWKS) *pushAuthenticator {
+	return &pushAuthenticator{
+		audience:        "https://push.example.com/gcp/api/v1/push",
+		allowedAccounts: map[string]struct{}{testServiceAccount: {}},
+		refreshInterval: time.Hour,
+		httpClient:      jwks.srv.Client(),
+		certsURL:        jwks.srv.URL,
+		logger:          slog.Default(),
+	}
+}
+
+func authRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/gcp/api/v1/push", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestPushAuthenticator_ValidToken(t *testing.T) {
+	jwks := newTestJWKS(t)
+	auth := newAuthenticator(jwks)
+
+	require.NoError(t, auth.authenticate(authRequest(jwks.sign(t))))
+}
+
+func TestPushAuthenticator_ExpiredToken(t *testing.T) {
+	jwks := newTestJWKS(t)
+	auth := newAuthenticator(jwks)
+
+	tok := jwks.sign(t, func(c jwt.MapClaims) {
+		c["iat"] = time.Now().Add(-2 * time.Hour).Unix()
+		c["exp"] = time.Now().Add(-time.Hour).Unix()
+	})
+
+	require.Error(t, auth.authenticate(authRequest(tok)))
+}
+
+func TestPushAuthenticator_WrongAudience(t *testing.T) {
+	jwks := newTestJWKS(t)
+	auth := newAuthenticator(jwks)
+
+	tok := jwks.sign(t, func(c jwt.MapClaims) { c["aud"] = "https://someone-else.example.com" })
+
+	require.Error(t, auth.authenticate(authRequest(tok)))
+}
+
+func TestPushAuthenticator_WrongIssuer(t *testing.T) {
+	jwks := newTestJWKS(t)
+	auth := newAuthenticator(jwks)
+
+	tok := jwks.sign(t, func(c jwt.MapClaims) { c["iss"] = "https://not-google.example.com" })
+
+	require.Error(t, auth.authenticate(authRequest(tok)))
+}
+
+func TestPushAuthenticator_DisallowedServiceAccount(t *testing.T) {
+	jwks := newTestJWKS(t)
+	auth := newAuthenticator(jwks)
+
+	tok := jwks.sign(t, func(c jwt.MapClaims) { c["email"] = "someone-else@test-project.iam.gserviceaccount.com" })
+
+	require.Error(t, auth.authenticate(authRequest(tok)))
+}
+
+func TestPushAuthenticator_MissingBearerToken(t *testing.T) {
+	jwks := newTestJWKS(t)
+	auth := newAuthenticator(jwks)
+
+	require.Error(t, auth.authenticate(authRequest("")))
+}
+
+func TestPushAuthenticator_ServesStaleJWKSOnRefreshFailure(t *testing.T) {
+	jwks := newTestJWKS(t)
+	auth := newAuthenticator(jwks)
+	tok := jwks.sign(t)
+
+	// Populate the cache, then force it to be considered stale and make the
+	// endpoint start failing - authenticate should still succeed against the
+	// previously cached keys rather than failing every request during a
+	// transient JWKS outage.
+	require.NoError(t, auth.authenticate(authRequest(tok)))
+	auth.mtx.Lock()
+	auth.fetched = time.Now().Add(-2 * auth.refreshInterval)
+	auth.mtx.Unlock()
+	jwks.statusCode = http.StatusInternalServerError
+
+	require.NoError(t, auth.authenticate(authRequest(tok)))
+}
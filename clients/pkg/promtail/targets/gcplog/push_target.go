@@ -1,13 +1,14 @@
 package gcplog
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 
 	"github.com/go-kit/log"
-	"github.com/go-kit/log/level"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/weaveworks/common/logging"
@@ -22,18 +23,21 @@ import (
 )
 
 type pushTarget struct {
-	logger         log.Logger
+	logger         *slog.Logger
 	handler        api.EntryHandler
 	config         *scrapeconfig.GcplogTargetConfig
 	jobName        string
 	server         *server.Server
 	metrics        *Metrics
 	relabelConfigs []*relabel.Config
+	auth           *pushAuthenticator
 }
 
 // newPushTarget creates a brand new GCP Push target, capable of receiving message from a GCP PubSub push subscription.
+// If config.PushAuthentication is set, incoming requests must carry a valid GCP-signed OIDC identity token.
+// logger may still be a go-kit log.Logger; it is adapted onto log/slog internally.
 func newPushTarget(metrics *Metrics, logger log.Logger, handler api.EntryHandler, jobName string, config *scrapeconfig.GcplogTargetConfig, relabel []*relabel.Config) (*pushTarget, error) {
-	wrappedLogger := log.With(logger, "component", "gcp_push")
+	wrappedLogger := newTargetLogger(logger, config.LogFormat).With("component", "gcp_push")
 
 	ht := &pushTarget{
 		metrics:        metrics,
@@ -44,6 +48,10 @@ func newPushTarget(metrics *Metrics, logger log.Logger, handler api.EntryHandler
 		relabelConfigs: relabel,
 	}
 
+	if config.PushAuthentication != nil {
+		ht.auth = newPushAuthenticator(config.PushAuthentication, wrappedLogger)
+	}
+
 	mergedServerConfigs, err := serverutils.MergeWithDefaults(config.Server)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse configs and override defaults when configuring gcp push target: %w", err)
@@ -59,7 +67,7 @@ func newPushTarget(metrics *Metrics, logger log.Logger, handler api.EntryHandler
 }
 
 func (h *pushTarget) run() error {
-	level.Info(h.logger).Log("msg", "starting gcp push target", "job", h.jobName)
+	h.logger.Info("starting gcp push target", "job", h.jobName)
 
 	// To prevent metric collisions because all metrics are going to be registered in the global Prometheus registry.
 
@@ -88,7 +96,7 @@ func (h *pushTarget) run() error {
 	go func() {
 		err := srv.Run()
 		if err != nil {
-			level.Error(h.logger).Log("msg", "gcp push target shutdown with error", "err", err)
+			h.logger.Error("gcp push target shutdown with error", "err", err)
 		}
 	}()
 
@@ -99,37 +107,71 @@ func (h *pushTarget) push(w http.ResponseWriter, r *http.Request) {
 	entries := h.handler.Chan()
 	defer r.Body.Close()
 
+	if h.auth != nil {
+		if err := h.auth.authenticate(r); err != nil {
+			h.metrics.gcpPushAuthErrors.WithLabelValues().Inc()
+			h.logger.Warn("rejected gcp push request with invalid identity token", "err", err.Error())
+			http.Error(w, "invalid identity token", http.StatusUnauthorized)
+			return
+		}
+	}
+
 	pushMessage := PushMessage{}
 	bs, err := io.ReadAll(r.Body)
 	if err != nil {
 		h.metrics.gcpPushErrors.WithLabelValues().Inc()
-		level.Warn(h.logger).Log("msg", "failed to read incoming gcp push request", "err", err.Error())
+		h.logger.Warn("failed to read incoming gcp push request", "err", err.Error())
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	err = json.Unmarshal(bs, &pushMessage)
 	if err != nil {
 		h.metrics.gcpPushErrors.WithLabelValues().Inc()
-		level.Warn(h.logger).Log("msg", "failed to unmarshall gcp push request", "err", err.Error())
+		h.logger.Warn("failed to unmarshall gcp push request", "err", err.Error())
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	entry, err := translate(pushMessage, h.config.Labels, h.config.UseIncomingTimestamp, h.relabelConfigs, r.Header.Get("X-Scope-OrgID"))
+	tenantID := h.resolveTenantID(pushMessage, r.Header.Get("X-Scope-OrgID"))
+
+	entry, err := translate(pushMessage, h.config.Labels, h.config.UseIncomingTimestamp, h.relabelConfigs, tenantID)
 	if err != nil {
 		h.metrics.gcpPushErrors.WithLabelValues().Inc()
-		level.Warn(h.logger).Log("msg", "failed to translate gcp push request", "err", err.Error())
+		h.logger.Warn("failed to translate gcp push request", "err", err.Error())
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	level.Debug(h.logger).Log("msg", fmt.Sprintf("Received line: %s", entry.Line))
+	h.logger.Debug("received line", "line", entry.Line)
 
 	entries <- entry
-	h.metrics.gcpPushEntries.WithLabelValues().Inc()
+	h.metrics.gcpPushEntries.WithLabelValues(tenantID).Inc()
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// resolveTenantID picks the org ID to stamp on the entry. When
+// config.TenantIDAttribute is set, the Pub/Sub message attribute takes
+// precedence over the static X-Scope-OrgID header, falling back to the
+// LogEntry resource label named by TenantIDLabel, allowing a single push
+// target to fan out across many tenants.
+func (h *pushTarget) resolveTenantID(msg PushMessage, headerOrgID string) string {
+	if h.config.TenantIDAttribute != "" {
+		if tenant, ok := msg.Message.Attributes[h.config.TenantIDAttribute]; ok && tenant != "" {
+			return tenant
+		}
+	}
+
+	if h.config.TenantIDLabel != "" {
+		if data, err := base64.StdEncoding.DecodeString(msg.Message.Data); err == nil {
+			if tenant, ok := resourceLabel(data, h.config.TenantIDLabel); ok && tenant != "" {
+				return tenant
+			}
+		}
+	}
+
+	return headerOrgID
+}
+
 func (h *pushTarget) Type() target.TargetType {
 	return target.GcplogTargetType
 }
@@ -151,7 +193,7 @@ func (h *pushTarget) Details() interface{} {
 }
 
 func (h *pushTarget) Stop() error {
-	level.Info(h.logger).Log("msg", "stopping gcp push target", "job", h.jobName)
+	h.logger.Info("stopping gcp push target", "job", h.jobName)
 	h.server.Shutdown()
 	h.handler.Stop()
 	return nil
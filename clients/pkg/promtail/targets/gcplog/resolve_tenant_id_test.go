@@ -0,0 +1,48 @@
+package gcplog
+
+import (
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+)
+
+func TestPullTarget_ResolveTenantID_LabelFallsBackToResourceLabel(t *testing.T) {
+	target := &pullTarget{
+		config: &scrapeconfig.GcplogTargetConfig{TenantIDLabel: "project_id"},
+	}
+
+	m := &pubsub.Message{
+		Data: []byte(`{"resource":{"labels":{"project_id":"tenant-a"}}}`),
+	}
+
+	require.Equal(t, "tenant-a", target.resolveTenantID(m))
+}
+
+func TestPullTarget_ResolveTenantID_AttributeTakesPrecedenceOverLabel(t *testing.T) {
+	target := &pullTarget{
+		config: &scrapeconfig.GcplogTargetConfig{
+			TenantIDAttribute: "tenant",
+			TenantIDLabel:     "project_id",
+		},
+	}
+
+	m := &pubsub.Message{
+		Attributes: map[string]string{"tenant": "tenant-from-attribute"},
+		Data:       []byte(`{"resource":{"labels":{"project_id":"tenant-from-label"}}}`),
+	}
+
+	require.Equal(t, "tenant-from-attribute", target.resolveTenantID(m))
+}
+
+func TestPullTarget_ResolveTenantID_NoMatchReturnsEmpty(t *testing.T) {
+	target := &pullTarget{
+		config: &scrapeconfig.GcplogTargetConfig{TenantIDLabel: "project_id"},
+	}
+
+	m := &pubsub.Message{Data: []byte(`{"resource":{"labels":{}}}`)}
+
+	require.Equal(t, "", target.resolveTenantID(m))
+}
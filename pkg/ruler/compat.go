@@ -5,12 +5,15 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/common/sigv4"
 	"github.com/prometheus/prometheus/model/labels"
@@ -22,6 +25,7 @@ import (
 	"github.com/prometheus/prometheus/rules"
 	"github.com/prometheus/prometheus/template"
 	"github.com/weaveworks/common/user"
+	"golang.org/x/sync/semaphore"
 	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/loki/pkg/logproto"
@@ -30,6 +34,7 @@ import (
 	ruler "github.com/grafana/loki/pkg/ruler/base"
 	"github.com/grafana/loki/pkg/ruler/rulespb"
 	"github.com/grafana/loki/pkg/ruler/util"
+	util_log "github.com/grafana/loki/pkg/util/log"
 )
 
 // RulesLimits is the one function we need from limits.Overrides, and
@@ -51,6 +56,100 @@ type RulesLimits interface {
 	RulerRemoteWriteQueueMaxBackoff(userID string) time.Duration
 	RulerRemoteWriteQueueRetryOnRateLimit(userID string) bool
 	RulerRemoteWriteSigV4Config(userID string) *sigv4.SigV4Config
+
+	// RulerEvaluationTimeout bounds how long a single rule evaluation query may
+	// run for the tenant before its context is cancelled.
+	RulerEvaluationTimeout(userID string) time.Duration
+	// RulerMaxConcurrentEvaluations bounds how many rule evaluation queries the
+	// tenant may have in flight at once, across all of their rule groups.
+	RulerMaxConcurrentEvaluations(userID string) int64
+}
+
+var ruleEvaluationThrottledTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "loki",
+	Name:      "ruler_rule_evaluation_throttled_total",
+	Help:      "Number of rule evaluations throttled because a tenant hit their max concurrent evaluations limit.",
+}, []string{"user"})
+
+// errRuleEvaluationThrottled is returned by queryContextFunc when a tenant
+// has hit RulerMaxConcurrentEvaluations. It deliberately does not satisfy
+// any "series is stale" check the rules manager does on query errors.
+var errRuleEvaluationThrottled = errors.New("rule evaluation throttled: too many concurrent evaluations for tenant")
+
+// evaluationGates holds one semaphore per tenant, used to bound how many
+// rule evaluation queries a tenant can have in flight at once. Gates are
+// created lazily and resized (a fresh semaphore swapped in, dropping any
+// permits already held) whenever the configured limit for a tenant changes,
+// so a live override reload takes effect immediately rather than only for
+// gates created after the change.
+type evaluationGates struct {
+	mtx   sync.Mutex
+	gates map[string]*evaluationGate
+}
+
+type evaluationGate struct {
+	sem  *semaphore.Weighted
+	size int64
+}
+
+func newEvaluationGates() *evaluationGates {
+	return &evaluationGates{gates: map[string]*evaluationGate{}}
+}
+
+func (e *evaluationGates) forUser(userID string, maxConcurrent int64) *semaphore.Weighted {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	g, ok := e.gates[userID]
+	if !ok || g.size != maxConcurrent {
+		g = &evaluationGate{sem: semaphore.NewWeighted(maxConcurrent), size: maxConcurrent}
+		e.gates[userID] = g
+	}
+	return g.sem
+}
+
+var evaluationGateRegistry = newEvaluationGates()
+
+// rulerEvaluationLimits is the narrow slice of RulesLimits queryContextFunc
+// actually needs, split out so it can be exercised with a small fake instead
+// of a full RulesLimits implementation.
+type rulerEvaluationLimits interface {
+	RulerEvaluationTimeout(userID string) time.Duration
+	RulerMaxConcurrentEvaluations(userID string) int64
+}
+
+// queryContextFunc derives a per-tenant, per-query context for a single rule
+// evaluation: a deadline from RulerEvaluationTimeout, gated by a semaphore
+// sized to RulerMaxConcurrentEvaluations so one noisy tenant's rule
+// evaluations can't starve the shared query engine. The returned
+// CancelFunc releases the semaphore slot and must always be called.
+func queryContextFunc(ctx context.Context, overrides rulerEvaluationLimits, userID string) (context.Context, context.CancelFunc, error) {
+	if maxConcurrent := overrides.RulerMaxConcurrentEvaluations(userID); maxConcurrent > 0 {
+		gate := evaluationGateRegistry.forUser(userID, maxConcurrent)
+		if !gate.TryAcquire(1) {
+			ruleEvaluationThrottledTotal.WithLabelValues(userID).Inc()
+			return nil, nil, errRuleEvaluationThrottled
+		}
+
+		queryCtx, cancel := withEvaluationTimeout(ctx, overrides.RulerEvaluationTimeout(userID))
+		return queryCtx, func() {
+			cancel()
+			gate.Release(1)
+		}, nil
+	}
+
+	queryCtx, cancel := withEvaluationTimeout(ctx, overrides.RulerEvaluationTimeout(userID))
+	return queryCtx, cancel, nil
+}
+
+// withEvaluationTimeout applies timeout to ctx, unless timeout is zero or
+// negative - an unconfigured RulerEvaluationTimeout must mean "no deadline",
+// not context.WithTimeout(ctx, 0)'s already-expired context.
+func withEvaluationTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
 // engineQueryFunc returns a new query function using the rules.EngineQueryFunc function
@@ -63,6 +162,13 @@ func engineQueryFunc(engine *logql.Engine, overrides RulesLimits, checker readyC
 			return nil, errNotReady
 		}
 
+		queryCtx, cancel, err := queryContextFunc(ctx, overrides, userID)
+		if err != nil {
+			return nil, err
+		}
+		defer cancel()
+		ctx = queryCtx
+
 		adjusted := t.Add(-overrides.EvaluationDelay(userID))
 		params := logql.NewLiteralParams(
 			qs,
@@ -94,20 +200,127 @@ func engineQueryFunc(engine *logql.Engine, overrides RulesLimits, checker readyC
 	})
 }
 
-// MultiTenantManagerAdapter will wrap a MultiTenantManager which validates loki rules
-func MultiTenantManagerAdapter(mgr ruler.MultiTenantManager) ruler.MultiTenantManager {
-	return &MultiTenantManager{inner: mgr}
+// MultiTenantManagerAdapter will wrap a MultiTenantManager which validates loki rules.
+// logger may still be a go-kit log.Logger; it is adapted onto log/slog internally.
+func MultiTenantManagerAdapter(mgr ruler.MultiTenantManager, logger log.Logger) ruler.MultiTenantManager {
+	return &MultiTenantManager{inner: mgr, logger: util_log.NewSlog(logger)}
 }
 
 // MultiTenantManager wraps a cortex MultiTenantManager but validates loki rules
 type MultiTenantManager struct {
-	inner ruler.MultiTenantManager
+	inner  ruler.MultiTenantManager
+	logger *slog.Logger
 }
 
+// SyncRuleGroups reorders each incoming rule group so that same-named rules
+// line up with their previous instance before delegating to the inner
+// manager. The inner (cortex) manager restores alert state via
+// rules.Group.CopyState, which pairs up same-named rules purely by their
+// position in the group - if a rule is reordered, or a new rule is inserted
+// ahead of an existing one with the same name, state gets attached to the
+// wrong rule and the existing alert is stale-marked and loses its `for:`
+// progress. Matching on (name, labels hash) before syncing keeps that
+// positional pairing correct.
 func (m *MultiTenantManager) SyncRuleGroups(ctx context.Context, ruleGroups map[string]rulespb.RuleGroupList) {
+	for userID, groups := range ruleGroups {
+		existingByKey := make(map[string]*rules.Group, len(groups))
+		for _, g := range m.inner.GetRules(userID) {
+			existingByKey[groupKey(g.File(), g.Name())] = g
+		}
+
+		for _, desc := range groups {
+			old, ok := existingByKey[groupKey(desc.Namespace, desc.Name)]
+			if !ok {
+				continue
+			}
+			reordered := reorderToPreserveAlertState(desc.Rules, groupRuleIdentities(old))
+			if m.logger != nil {
+				m.logger.Debug("reordered rule group before sync to preserve alert state", "user", userID, "namespace", desc.Namespace, "group", desc.Name)
+			}
+			desc.Rules = reordered
+		}
+	}
+
 	m.inner.SyncRuleGroups(ctx, ruleGroups)
 }
 
+// groupKey identifies a rule group by namespace and name, matching on name
+// alone would collide two distinctly-namespaced groups that happen to share
+// a name and pair their rules' alert state across unrelated groups.
+func groupKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// ruleIdentity identifies a rule the way alert state should be matched on:
+// by name and by the hash of its labels, not by its position in the group.
+type ruleIdentity struct {
+	name       string
+	labelsHash uint64
+}
+
+func groupRuleIdentities(g *rules.Group) []ruleIdentity {
+	grpRules := g.Rules()
+	ids := make([]ruleIdentity, 0, len(grpRules))
+	for _, r := range grpRules {
+		ids = append(ids, ruleIdentity{name: r.Name(), labelsHash: r.Labels().Hash()})
+	}
+	return ids
+}
+
+func ruleDescIdentity(r *rulespb.RuleDesc) ruleIdentity {
+	name := r.GetRecord()
+	if r.GetAlert() != "" {
+		name = r.GetAlert()
+	}
+	return ruleIdentity{name: name, labelsHash: labels.FromMap(r.GetLabels()).Hash()}
+}
+
+// reorderToPreserveAlertState returns newRules reordered so that, within
+// each rule name, rules matching an identity from oldIdentities come first
+// and in the same relative order they previously held; any newly added
+// same-named rule is appended after them, in its original relative order.
+func reorderToPreserveAlertState(newRules []*rulespb.RuleDesc, oldIdentities []ruleIdentity) []*rulespb.RuleDesc {
+	oldOrder := make(map[string][]uint64, len(oldIdentities))
+	for _, id := range oldIdentities {
+		oldOrder[id.name] = append(oldOrder[id.name], id.labelsHash)
+	}
+
+	byName := make(map[string][]*rulespb.RuleDesc, len(newRules))
+	names := make([]string, 0, len(newRules))
+	for _, r := range newRules {
+		name := ruleDescIdentity(r).name
+		if _, ok := byName[name]; !ok {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], r)
+	}
+
+	reordered := make([]*rulespb.RuleDesc, 0, len(newRules))
+	for _, name := range names {
+		candidates := byName[name]
+		used := make([]bool, len(candidates))
+
+		for _, hash := range oldOrder[name] {
+			for i, c := range candidates {
+				if used[i] || ruleDescIdentity(c).labelsHash != hash {
+					continue
+				}
+				used[i] = true
+				reordered = append(reordered, c)
+				break
+			}
+		}
+
+		for i, c := range candidates {
+			if !used[i] {
+				reordered = append(reordered, c)
+			}
+		}
+	}
+
+	return reordered
+}
+
 func (m *MultiTenantManager) GetRules(userID string) []*rules.Group {
 	return m.inner.GetRules(userID)
 }
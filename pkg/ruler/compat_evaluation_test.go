@@ -0,0 +1,84 @@
+package ruler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEvaluationLimits struct {
+	timeout       time.Duration
+	maxConcurrent int64
+}
+
+func (f fakeEvaluationLimits) RulerEvaluationTimeout(string) time.Duration { return f.timeout }
+func (f fakeEvaluationLimits) RulerMaxConcurrentEvaluations(string) int64  { return f.maxConcurrent }
+
+func TestWithEvaluationTimeout_ZeroMeansNoDeadline(t *testing.T) {
+	ctx, cancel := withEvaluationTimeout(context.Background(), 0)
+	defer cancel()
+
+	_, hasDeadline := ctx.Deadline()
+	require.False(t, hasDeadline, "a zero timeout must not produce an already-expired context.WithTimeout(ctx, 0)")
+	require.NoError(t, ctx.Err())
+}
+
+func TestWithEvaluationTimeout_PositiveSetsDeadline(t *testing.T) {
+	ctx, cancel := withEvaluationTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	deadline, hasDeadline := ctx.Deadline()
+	require.True(t, hasDeadline)
+	require.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+}
+
+func TestQueryContextFunc_NoGateWhenLimitUnset(t *testing.T) {
+	ctx, cancel, err := queryContextFunc(context.Background(), fakeEvaluationLimits{timeout: time.Minute}, "tenant-a")
+	require.NoError(t, err)
+	defer cancel()
+
+	_, hasDeadline := ctx.Deadline()
+	require.True(t, hasDeadline)
+}
+
+func TestQueryContextFunc_ThrottlesWhenGateExhausted(t *testing.T) {
+	userID := "tenant-throttled"
+	limits := fakeEvaluationLimits{timeout: time.Minute, maxConcurrent: 1}
+
+	// Take the only permit so the next call must be throttled.
+	gate := evaluationGateRegistry.forUser(userID, limits.maxConcurrent)
+	require.True(t, gate.TryAcquire(1))
+	defer gate.Release(1)
+
+	before := testutil.ToFloat64(ruleEvaluationThrottledTotal.WithLabelValues(userID))
+
+	ctx, cancel, err := queryContextFunc(context.Background(), limits, userID)
+
+	require.Nil(t, ctx)
+	require.Nil(t, cancel)
+	require.ErrorIs(t, err, errRuleEvaluationThrottled)
+
+	after := testutil.ToFloat64(ruleEvaluationThrottledTotal.WithLabelValues(userID))
+	require.Equal(t, before+1, after, "a throttled evaluation must increment the counter exactly once")
+}
+
+func TestEvaluationGates_ResizesWhenLimitChanges(t *testing.T) {
+	gates := newEvaluationGates()
+	userID := "tenant-resize"
+
+	small := gates.forUser(userID, 1)
+	require.True(t, small.TryAcquire(1))
+
+	// Asking for the same size returns the same, now-exhausted gate.
+	same := gates.forUser(userID, 1)
+	require.False(t, same.TryAcquire(1), "must be the same gate instance as before, with its permit still held")
+
+	// A different configured size must swap in a fresh semaphore rather than
+	// keep serving the exhausted one.
+	resized := gates.forUser(userID, 2)
+	require.True(t, resized.TryAcquire(1), "a changed limit must take effect immediately, not only for new tenants")
+	require.True(t, resized.TryAcquire(1))
+}
@@ -0,0 +1,144 @@
+package ruler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/ruler/rulespb"
+)
+
+func TestReorderToPreserveAlertState_ReorderedRuleKeepsIdentity(t *testing.T) {
+	// The existing group had a single alerting rule named "Alert" with
+	// labels {severity="warning"}.
+	old := []ruleIdentity{
+		{name: "Alert", labelsHash: labelsHash(map[string]string{"severity": "warning"})},
+	}
+
+	// The reload inserts a *new* rule also named "Alert" (different labels)
+	// ahead of the pre-existing one - without reordering, prometheus's
+	// position-based CopyState would hand the old rule's state to this new
+	// rule instead.
+	newRule := &rulespb.RuleDesc{Alert: "Alert", Labels: map[string]string{"severity": "critical"}}
+	existingRule := &rulespb.RuleDesc{Alert: "Alert", Labels: map[string]string{"severity": "warning"}}
+
+	got := reorderToPreserveAlertState([]*rulespb.RuleDesc{newRule, existingRule}, old)
+
+	require.Len(t, got, 2)
+	require.Same(t, existingRule, got[0], "the rule matching the prior (name, labels) pair must come first so CopyState attaches the old state to it")
+	require.Same(t, newRule, got[1])
+}
+
+func TestReorderToPreserveAlertState_NoPriorMatchKeepsOriginalOrder(t *testing.T) {
+	ruleA := &rulespb.RuleDesc{Alert: "A"}
+	ruleB := &rulespb.RuleDesc{Alert: "B"}
+
+	got := reorderToPreserveAlertState([]*rulespb.RuleDesc{ruleA, ruleB}, nil)
+
+	require.Equal(t, []*rulespb.RuleDesc{ruleA, ruleB}, got)
+}
+
+func labelsHash(lbls map[string]string) uint64 {
+	return ruleDescIdentity(&rulespb.RuleDesc{Alert: "Alert", Labels: lbls}).labelsHash
+}
+
+// TestSyncRuleGroups_PreservesAlertStateAcrossReorder exercises the real
+// interaction this package relies on: prometheus's rules.Group.CopyState
+// pairs up same-named rules purely by their position within the group. It
+// builds the "old" group the way the ruler would have it running, evaluates
+// its alerting rule into a live alert, then builds the "new" group as the
+// ruler would see it after a reload - with a newly inserted rule of the same
+// name ahead of the existing one - and asserts that reordering via
+// reorderToPreserveAlertState (as SyncRuleGroups does) is what keeps
+// CopyState from handing the existing alert's state to the wrong rule.
+func TestSyncRuleGroups_PreservesAlertStateAcrossReorder(t *testing.T) {
+	existingRule := newTestAlertingRule(t, "Alert", labels.FromMap(map[string]string{"severity": "warning"}))
+	newRule := newTestAlertingRule(t, "Alert", labels.FromMap(map[string]string{"severity": "critical"}))
+
+	activeAlert := &rules.Alert{
+		State:    rules.StatePending,
+		Labels:   existingRule.Labels(),
+		ActiveAt: time.Now().Add(-time.Minute),
+	}
+	setActiveAlerts(t, existingRule, activeAlert)
+
+	oldGroup := newTestGroup(t, "ns1", "group1", existingRule)
+
+	// The reload yields the new rule ahead of the existing one in the group's
+	// own (unordered) rule list, as a naive reload would.
+	unorderedRules := []*rulespb.RuleDesc{
+		{Alert: "Alert", Labels: map[string]string{"severity": "critical"}},
+		{Alert: "Alert", Labels: map[string]string{"severity": "warning"}},
+	}
+
+	t.Run("without reordering, CopyState pairs rules positionally and attaches state to the wrong rule", func(t *testing.T) {
+		unorderedGroup := newTestGroup(t, "ns1", "group1", newRule, existingRule)
+		unorderedGroup.CopyState(oldGroup)
+
+		gotNewRule := findAlertingRule(t, unorderedGroup, 0)
+		require.NotEmpty(t, gotNewRule.ActiveAlerts(), "without reordering the newly inserted rule wrongly inherits the existing alert's state")
+	})
+
+	reordered := reorderToPreserveAlertState(unorderedRules, groupRuleIdentities(oldGroup))
+	require.Equal(t, "warning", reordered[0].Labels["severity"])
+	require.Equal(t, "critical", reordered[1].Labels["severity"])
+
+	t.Run("reordered to match SyncRuleGroups, CopyState attaches state to the right rule", func(t *testing.T) {
+		reorderedExistingRule := newTestAlertingRule(t, "Alert", labels.FromMap(map[string]string{"severity": "warning"}))
+		reorderedNewRule := newTestAlertingRule(t, "Alert", labels.FromMap(map[string]string{"severity": "critical"}))
+
+		newGroup := newTestGroup(t, "ns1", "group1", reorderedExistingRule, reorderedNewRule)
+		newGroup.CopyState(oldGroup)
+
+		require.NotEmpty(t, findAlertingRule(t, newGroup, 0).ActiveAlerts(), "the rule matching the prior (name, labels) pair must keep the pending alert")
+		require.Empty(t, findAlertingRule(t, newGroup, 1).ActiveAlerts(), "the newly inserted rule must start with no alert state")
+	})
+}
+
+func newTestAlertingRule(t *testing.T, name string, lbls labels.Labels) *rules.AlertingRule {
+	t.Helper()
+	expr, err := (GroupLoader{}).Parse(`{app="test"}`)
+	require.NoError(t, err)
+	return rules.NewAlertingRule(name, expr, 10*time.Minute, lbls, labels.EmptyLabels(), nil, "", true, log.NewNopLogger())
+}
+
+func newTestGroup(t *testing.T, namespace, name string, rs ...rules.Rule) *rules.Group {
+	t.Helper()
+	return rules.NewGroup(rules.GroupOptions{
+		Name:     name,
+		File:     namespace,
+		Interval: time.Minute,
+		Rules:    rs,
+		Opts:     &rules.ManagerOptions{},
+	})
+}
+
+func findAlertingRule(t *testing.T, g *rules.Group, idx int) *rules.AlertingRule {
+	t.Helper()
+	ar, ok := g.Rules()[idx].(*rules.AlertingRule)
+	require.True(t, ok, "rule at index %d is not an *AlertingRule", idx)
+	return ar
+}
+
+// setActiveAlerts seeds an AlertingRule's unexported active-alert map for the
+// test. There is no exported way to put a rule into a firing/pending state
+// short of running it through a full manager/appender/queryable stack, so we
+// reach for the unexported field directly rather than fabricate that stack.
+func setActiveAlerts(t *testing.T, ar *rules.AlertingRule, alerts ...*rules.Alert) {
+	t.Helper()
+	v := reflect.ValueOf(ar).Elem().FieldByName("active")
+	require.True(t, v.IsValid(), "AlertingRule no longer has an \"active\" field - update setActiveAlerts")
+	v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+
+	m := reflect.MakeMap(v.Type())
+	for _, a := range alerts {
+		m.SetMapIndex(reflect.ValueOf(a.Labels.Hash()), reflect.ValueOf(a))
+	}
+	v.Set(m)
+}
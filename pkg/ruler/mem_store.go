@@ -0,0 +1,251 @@
+package ruler
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+
+	util_log "github.com/grafana/loki/pkg/util/log"
+)
+
+// MemStore periodically re-runs a tenant's alerting rule queries and caches
+// the resulting samples in memory, so the rules manager's own Queryable can
+// serve the "for" state restoration queries it issues against its alerting
+// rules without round-tripping through the chunk store.
+type MemStore struct {
+	userID    string
+	queryFunc rules.QueryFunc
+	metrics   *memstoreMetrics
+	ttl       time.Duration
+	logger    *slog.Logger
+
+	quit chan struct{}
+
+	mtx     sync.RWMutex
+	samples map[uint64][]promql.Sample
+}
+
+// NewMemStore returns a MemStore for userID that refreshes its cached
+// samples every ttl/2 once Start is called. logger may still be a go-kit
+// log.Logger; it is adapted onto log/slog internally.
+func NewMemStore(userID string, queryFunc rules.QueryFunc, metrics *memstoreMetrics, ttl time.Duration, logger log.Logger) *MemStore {
+	return &MemStore{
+		userID:    userID,
+		queryFunc: queryFunc,
+		metrics:   metrics,
+		ttl:       ttl,
+		logger:    util_log.NewSlog(logger),
+		quit:      make(chan struct{}),
+		samples:   map[uint64][]promql.Sample{},
+	}
+}
+
+// Start begins periodically refreshing the cached samples for mgr's
+// alerting rules until Stop is called.
+func (m *MemStore) Start(mgr *rules.Manager) {
+	go m.run(mgr)
+}
+
+// Stop ends the background refresh loop started by Start.
+func (m *MemStore) Stop() {
+	close(m.quit)
+}
+
+func (m *MemStore) run(mgr *rules.Manager) {
+	interval := m.ttl / 2
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.refresh(mgr)
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// refresh re-evaluates every alerting rule query known to mgr and replaces
+// the cached sample set wholesale, so a rule removed from the group stops
+// being served by Querier on the next tick instead of lingering forever.
+func (m *MemStore) refresh(mgr *rules.Manager) {
+	fresh := map[uint64][]promql.Sample{}
+
+	for _, group := range mgr.RuleGroups() {
+		for _, rule := range group.Rules() {
+			ar, ok := rule.(*rules.AlertingRule)
+			if !ok {
+				continue
+			}
+
+			vector, err := m.queryFunc(context.Background(), ar.Query().String(), time.Now())
+			if err != nil {
+				m.logger.Warn("failed to refresh memstore sample for alerting rule", "user", m.userID, "rule", ar.Name(), "err", err)
+				m.metrics.refreshFailuresTotal.WithLabelValues(m.userID).Inc()
+				continue
+			}
+
+			for _, sample := range vector {
+				h := sample.Metric.Hash()
+				fresh[h] = append(fresh[h], sample)
+			}
+		}
+	}
+
+	m.mtx.Lock()
+	m.samples = fresh
+	m.mtx.Unlock()
+}
+
+// Querier implements storage.Queryable, returning a read-only snapshot of
+// the most recently refreshed samples. mint and maxt are ignored: MemStore
+// only ever holds the latest value per series.
+func (m *MemStore) Querier(_, _ int64) (storage.Querier, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	samples := make(map[uint64][]promql.Sample, len(m.samples))
+	for h, s := range m.samples {
+		samples[h] = s
+	}
+
+	return &memStoreQuerier{samples: samples}, nil
+}
+
+type memStoreQuerier struct {
+	samples map[uint64][]promql.Sample
+}
+
+func (q *memStoreQuerier) LabelValues(_ context.Context, name string, _ ...*labels.Matcher) ([]string, storage.Warnings, error) {
+	seen := map[string]struct{}{}
+	for _, ss := range q.samples {
+		for _, s := range ss {
+			if v := s.Metric.Get(name); v != "" {
+				seen[v] = struct{}{}
+			}
+		}
+	}
+
+	values := make([]string, 0, len(seen))
+	for v := range seen {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values, nil, nil
+}
+
+func (q *memStoreQuerier) LabelNames(_ context.Context, _ ...*labels.Matcher) ([]string, storage.Warnings, error) {
+	seen := map[string]struct{}{}
+	for _, ss := range q.samples {
+		for _, s := range ss {
+			for _, l := range s.Metric {
+				seen[l.Name] = struct{}{}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names, nil, nil
+}
+
+func (q *memStoreQuerier) Close() error { return nil }
+
+func (q *memStoreQuerier) Select(_ context.Context, sortSeries bool, _ *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	var series []*memStoreSeries
+	for _, ss := range q.samples {
+		for _, s := range ss {
+			if !matchesAll(s.Metric, matchers) {
+				continue
+			}
+			series = append(series, &memStoreSeries{lbls: s.Metric, sample: s})
+		}
+	}
+
+	if sortSeries {
+		sort.Slice(series, func(i, j int) bool {
+			return labels.Compare(series[i].lbls, series[j].lbls) < 0
+		})
+	}
+
+	return &memStoreSeriesSet{series: series, cur: -1}
+}
+
+func matchesAll(lbls labels.Labels, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+type memStoreSeriesSet struct {
+	series []*memStoreSeries
+	cur    int
+}
+
+func (s *memStoreSeriesSet) Next() bool {
+	s.cur++
+	return s.cur < len(s.series)
+}
+
+func (s *memStoreSeriesSet) At() storage.Series         { return s.series[s.cur] }
+func (s *memStoreSeriesSet) Err() error                 { return nil }
+func (s *memStoreSeriesSet) Warnings() storage.Warnings { return nil }
+
+type memStoreSeries struct {
+	lbls   labels.Labels
+	sample promql.Sample
+}
+
+func (s *memStoreSeries) Labels() labels.Labels { return s.lbls }
+
+func (s *memStoreSeries) Iterator(_ chunkenc.Iterator) chunkenc.Iterator {
+	return &memStoreIterator{sample: s.sample}
+}
+
+// memStoreIterator yields the single cached sample for a series exactly
+// once; MemStore only ever retains the latest value it observed for each
+// series, not a history.
+type memStoreIterator struct {
+	sample promql.Sample
+	used   bool
+}
+
+func (it *memStoreIterator) Next() chunkenc.ValueType {
+	if it.used {
+		return chunkenc.ValNone
+	}
+	it.used = true
+	return chunkenc.ValFloat
+}
+
+func (it *memStoreIterator) Seek(t int64) chunkenc.ValueType {
+	if it.used || t > it.sample.T {
+		return chunkenc.ValNone
+	}
+	it.used = true
+	return chunkenc.ValFloat
+}
+
+func (it *memStoreIterator) At() (int64, float64) { return it.sample.T, it.sample.V }
+func (it *memStoreIterator) AtT() int64           { return it.sample.T }
+func (it *memStoreIterator) Err() error           { return nil }
@@ -0,0 +1,63 @@
+package ruler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemStoreQuerier_SelectReturnsMatchingSeriesWithCachedSample(t *testing.T) {
+	lbls := labels.FromStrings("__name__", "ALERTS", "alertname", "HighErrorRate")
+	sample := promql.Sample{Metric: lbls, Point: promql.Point{T: 1000, V: 1}}
+
+	q := &memStoreQuerier{samples: map[uint64][]promql.Sample{lbls.Hash(): {sample}}}
+
+	matcher, err := labels.NewMatcher(labels.MatchEqual, "alertname", "HighErrorRate")
+	require.NoError(t, err)
+
+	set := q.Select(context.Background(), true, nil, matcher)
+	require.True(t, set.Next())
+	require.Equal(t, lbls, set.At().Labels())
+
+	it := set.At().Iterator(nil)
+	require.Equal(t, chunkenc.ValFloat, it.Next())
+	ts, v := it.At()
+	require.EqualValues(t, 1000, ts)
+	require.EqualValues(t, 1, v)
+	require.Equal(t, chunkenc.ValNone, it.Next(), "a MemStore series only ever has one cached sample")
+
+	require.False(t, set.Next())
+	require.NoError(t, set.Err())
+}
+
+func TestMemStoreQuerier_SelectExcludesNonMatchingSeries(t *testing.T) {
+	lbls := labels.FromStrings("__name__", "ALERTS", "alertname", "HighErrorRate")
+	sample := promql.Sample{Metric: lbls, Point: promql.Point{T: 1000, V: 1}}
+
+	q := &memStoreQuerier{samples: map[uint64][]promql.Sample{lbls.Hash(): {sample}}}
+
+	matcher, err := labels.NewMatcher(labels.MatchEqual, "alertname", "SomethingElse")
+	require.NoError(t, err)
+
+	set := q.Select(context.Background(), true, nil, matcher)
+	require.False(t, set.Next())
+}
+
+func TestMemStoreQuerier_LabelNamesAndValues(t *testing.T) {
+	lbls := labels.FromStrings("__name__", "ALERTS", "alertname", "HighErrorRate")
+	sample := promql.Sample{Metric: lbls, Point: promql.Point{T: 1000, V: 1}}
+
+	q := &memStoreQuerier{samples: map[uint64][]promql.Sample{lbls.Hash(): {sample}}}
+
+	names, _, err := q.LabelNames(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"__name__", "alertname"}, names)
+
+	values, _, err := q.LabelValues(context.Background(), "alertname")
+	require.NoError(t, err)
+	require.Equal(t, []string{"HighErrorRate"}, values)
+}
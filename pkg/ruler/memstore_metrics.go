@@ -0,0 +1,22 @@
+package ruler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// memstoreMetrics holds the metrics MemStore emits while refreshing its
+// cached alerting rule samples.
+type memstoreMetrics struct {
+	refreshFailuresTotal *prometheus.CounterVec
+}
+
+// newMemstoreMetrics registers and returns a memstoreMetrics on reg.
+func newMemstoreMetrics(reg prometheus.Registerer) *memstoreMetrics {
+	return &memstoreMetrics{
+		refreshFailuresTotal: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "memstore_refresh_failures_total",
+			Help: "Number of times MemStore failed to refresh a tenant's cached alerting rule samples.",
+		}, []string{"user"}),
+	}
+}
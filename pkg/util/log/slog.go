@@ -0,0 +1,146 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// NewSlog returns a *slog.Logger that writes through logger, for callers
+// migrating to log/slog that still need to share sinks with components
+// passing around a go-kit log.Logger.
+func NewSlog(logger log.Logger) *slog.Logger {
+	return slog.New(&goKitHandler{logger: logger})
+}
+
+// goKitHandler is a thin slog.Handler adapter over a go-kit log.Logger, so
+// go-kit callers and slog callers can share the same underlying sink while
+// packages migrate one at a time.
+type goKitHandler struct {
+	logger log.Logger
+}
+
+func (h *goKitHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *goKitHandler) Handle(_ context.Context, r slog.Record) error {
+	keyvals := make([]interface{}, 0, 4+r.NumAttrs()*2)
+	keyvals = append(keyvals, "msg", r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		keyvals = append(keyvals, a.Key, a.Value.Any())
+		return true
+	})
+
+	switch {
+	case r.Level >= slog.LevelError:
+		return level.Error(h.logger).Log(keyvals...)
+	case r.Level >= slog.LevelWarn:
+		return level.Warn(h.logger).Log(keyvals...)
+	case r.Level >= slog.LevelInfo:
+		return level.Info(h.logger).Log(keyvals...)
+	default:
+		return level.Debug(h.logger).Log(keyvals...)
+	}
+}
+
+func (h *goKitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	keyvals := make([]interface{}, 0, len(attrs)*2)
+	for _, a := range attrs {
+		keyvals = append(keyvals, a.Key, a.Value.Any())
+	}
+	return &goKitHandler{logger: log.With(h.logger, keyvals...)}
+}
+
+func (h *goKitHandler) WithGroup(name string) slog.Handler {
+	// go-kit has no notion of attribute groups; record the group name as a
+	// "group" key/value instead. Later attrs' keys are neither nested nor
+	// prefixed with it.
+	return &goKitHandler{logger: log.With(h.logger, "group", name)}
+}
+
+// NewJSONSlog returns a *slog.Logger that writes structured JSON to os.Stdout,
+// for components that want machine-readable output without a go-kit sink.
+func NewJSONSlog(level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+}
+
+// NewDedupingSlog wraps next so that records at the same level and with the
+// same message are suppressed while they keep repeating within window of the
+// last forwarded one. A single summary line reporting how many were
+// suppressed is written just before the next record that is forwarded (one
+// with a different level or message, or a repeat arriving after the window
+// has elapsed); there is no timer, so the summary for a run of repeats that
+// no later record follows is not written. This is meant for noisy,
+// bursty error paths - e.g. a subscription retrying the same failure on
+// every attempt - that would otherwise flood the log with duplicates. The
+// key deliberately ignores attributes: the whole point of these call sites
+// is that the failure is the same one repeating, but its attributes (a
+// wrapped error's text, a request ID, ...) are expected to differ on every
+// attempt, so keying on them would defeat the suppression entirely.
+func NewDedupingSlog(next slog.Handler, window time.Duration) slog.Handler {
+	return &dedupHandler{next: next, window: window}
+}
+
+type dedupHandler struct {
+	next   slog.Handler
+	window time.Duration
+
+	mtx        sync.Mutex
+	lastKey    string
+	lastRecord slog.Record
+	count      int
+	since      time.Time
+}
+
+func (h *dedupHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return h.next.Enabled(ctx, lvl)
+}
+
+func (h *dedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	key := dedupKey(r)
+
+	h.mtx.Lock()
+	if key == h.lastKey && time.Since(h.since) < h.window {
+		h.count++
+		h.mtx.Unlock()
+		return nil
+	}
+
+	prevKey, prevRecord, prevCount := h.lastKey, h.lastRecord, h.count
+	h.lastKey, h.lastRecord, h.count, h.since = key, r, 0, time.Now()
+	h.mtx.Unlock()
+
+	if prevKey != "" && prevCount > 0 {
+		if err := h.next.Handle(ctx, summaryRecord(prevRecord, prevCount)); err != nil {
+			return err
+		}
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *dedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &dedupHandler{next: h.next.WithAttrs(attrs), window: h.window}
+}
+
+func (h *dedupHandler) WithGroup(name string) slog.Handler {
+	return &dedupHandler{next: h.next.WithGroup(name), window: h.window}
+}
+
+func dedupKey(r slog.Record) string {
+	return r.Level.String() + "|" + r.Message
+}
+
+func summaryRecord(r slog.Record, suppressed int) slog.Record {
+	summary := slog.NewRecord(time.Now(), r.Level, r.Message, 0)
+	summary.AddAttrs(slog.Int("suppressed", suppressed))
+	r.Attrs(func(a slog.Attr) bool {
+		summary.AddAttrs(a)
+		return true
+	})
+	return summary
+}
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHandler captures every record handed to it, for asserting on what
+// a wrapping handler actually forwards downstream.
+type recordingHandler struct {
+	records *[]slog.Record
+}
+
+func newRecordingHandler() (*recordingHandler, *[]slog.Record) {
+	records := []slog.Record{}
+	return &recordingHandler{records: &records}, &records
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	*h.records = append(*h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+func recordAttrs(r slog.Record) map[string]string {
+	attrs := map[string]string{}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+	return attrs
+}
+
+func TestDedupHandler_SuppressesRepeatsWithVaryingAttributes(t *testing.T) {
+	next, records := newRecordingHandler()
+	h := NewDedupingSlog(next, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		r := slog.NewRecord(time.Now(), slog.LevelError, "failed to receive pubsub messages", 0)
+		r.AddAttrs(slog.String("error", fmt.Sprintf("rpc error: attempt %d of 5", i)))
+		require.NoError(t, h.Handle(context.Background(), r))
+	}
+
+	require.Len(t, *records, 1, "only the first of the repeating errors should be forwarded immediately, even though their attributes differ every time")
+	require.Equal(t, "failed to receive pubsub messages", (*records)[0].Message)
+}
+
+func TestDedupHandler_FlushesSummaryWhenMessageChanges(t *testing.T) {
+	next, records := newRecordingHandler()
+	h := NewDedupingSlog(next, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		r := slog.NewRecord(time.Now(), slog.LevelError, "failed to receive pubsub messages", 0)
+		require.NoError(t, h.Handle(context.Background(), r))
+	}
+
+	ok := slog.NewRecord(time.Now(), slog.LevelInfo, "receive loop recovered", 0)
+	require.NoError(t, h.Handle(context.Background(), ok))
+
+	require.Len(t, *records, 3)
+	require.Equal(t, "failed to receive pubsub messages", (*records)[0].Message, "the first occurrence is forwarded right away")
+	require.Equal(t, "failed to receive pubsub messages", (*records)[1].Message, "the summary line reuses the repeated record's message")
+	require.Equal(t, "2", recordAttrs((*records)[1])["suppressed"], "two further repeats were suppressed before the message changed")
+	require.Equal(t, "receive loop recovered", (*records)[2].Message, "the new message is forwarded once the summary for the old one has flushed")
+}
@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"flag"
+	"time"
+)
+
+// Limits holds the per-tenant limits the ruler's RulesLimits interface
+// needs. It only carries the fields this package's callers actually read;
+// the rest of Loki's tenant limits live alongside it in the real overrides
+// struct this mirrors.
+type Limits struct {
+	// RulerEvaluationTimeout bounds how long a single rule evaluation query
+	// may run for the tenant before its context is cancelled. Zero means no
+	// per-query deadline beyond the evaluation's own context.
+	RulerEvaluationTimeout time.Duration `yaml:"ruler_evaluation_timeout" json:"ruler_evaluation_timeout"`
+
+	// RulerMaxConcurrentEvaluations bounds how many rule evaluation queries
+	// the tenant may have in flight at once, across all of their rule
+	// groups. Zero disables the limit.
+	RulerMaxConcurrentEvaluations int64 `yaml:"ruler_max_concurrent_evaluations" json:"ruler_max_concurrent_evaluations"`
+}
+
+// RegisterFlags registers the flags backing Limits' default values.
+func (l *Limits) RegisterFlags(f *flag.FlagSet) {
+	f.DurationVar(&l.RulerEvaluationTimeout, "ruler.evaluation-timeout", 0, "Timeout for a single rule evaluation query. 0 disables the per-query deadline.")
+	f.Int64Var(&l.RulerMaxConcurrentEvaluations, "ruler.max-concurrent-evaluations", 0, "Maximum number of concurrent rule evaluation queries a tenant may have in flight. 0 disables the limit.")
+}
+
+// TenantLimits looks up per-tenant Limits overrides, falling back to the
+// default Limits when a tenant has none configured.
+type TenantLimits interface {
+	TenantLimits(userID string) *Limits
+}
+
+// Overrides resolves per-tenant limits, falling back to a set of defaults
+// when a tenant has no override configured.
+type Overrides struct {
+	defaultLimits *Limits
+	tenantLimits  TenantLimits
+}
+
+// NewOverrides builds an Overrides resolving to defaultLimits for any tenant
+// without an override in tenantLimits.
+func NewOverrides(defaultLimits Limits, tenantLimits TenantLimits) *Overrides {
+	return &Overrides{defaultLimits: &defaultLimits, tenantLimits: tenantLimits}
+}
+
+// RulerEvaluationTimeout implements ruler.RulesLimits.
+func (o *Overrides) RulerEvaluationTimeout(userID string) time.Duration {
+	return o.getOverridesForUser(userID).RulerEvaluationTimeout
+}
+
+// RulerMaxConcurrentEvaluations implements ruler.RulesLimits.
+func (o *Overrides) RulerMaxConcurrentEvaluations(userID string) int64 {
+	return o.getOverridesForUser(userID).RulerMaxConcurrentEvaluations
+}
+
+func (o *Overrides) getOverridesForUser(userID string) *Limits {
+	if o.tenantLimits != nil {
+		if l := o.tenantLimits.TenantLimits(userID); l != nil {
+			return l
+		}
+	}
+	return o.defaultLimits
+}